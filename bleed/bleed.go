@@ -0,0 +1,342 @@
+// Package bleed computes the pixel content painted into the bleed border
+// added around a card image. It is kept separate from the CLI package so
+// the pixel-copy logic for each fill mode can be unit tested without
+// going through file I/O.
+package bleed
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Mode selects how the bleed border surrounding a card is filled.
+type Mode string
+
+const (
+	Black  Mode = "black"
+	White  Mode = "white"
+	Sample Mode = "sample"
+	Mirror Mode = "mirror"
+	Extend Mode = "extend"
+)
+
+// sampleRingPx is how many pixels deep the outermost ring of the source
+// image is scanned when computing a per-side median color in Sample mode.
+const sampleRingPx = 3
+
+// ParseMode validates a `-bleed` flag value, returning an error listing the
+// supported modes if it doesn't match one of them.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case Black, White, Sample, Mirror, Extend:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unknown bleed mode %q, expected one of: black, white, sample, mirror, extend", s)
+	}
+}
+
+// Paint fills the bleed border of dst - the ring between innerBounds (where
+// the card itself has already been drawn) and dst.Bounds() - according to
+// mode. src is the original, unbled card image that innerBounds was drawn
+// from; it's consulted directly by Sample, Mirror and Extend so the fill
+// never depends on draw order relative to the card itself.
+func Paint(dst *image.RGBA, src image.Image, innerBounds image.Rectangle, mode Mode) {
+	switch mode {
+	case White:
+		fillRegions(dst, color.RGBA{255, 255, 255, 255}, edgeRegions(dst.Bounds(), innerBounds))
+	case Sample:
+		paintSample(dst, src, innerBounds)
+	case Mirror:
+		paintMirror(dst, src, innerBounds)
+	case Extend:
+		paintExtend(dst, src, innerBounds)
+	default:
+		fillRegions(dst, color.RGBA{0, 0, 0, 255}, edgeRegions(dst.Bounds(), innerBounds))
+	}
+}
+
+// edges names the four bleed strips (excluding corners) and the four corner
+// squares, the corners in clockwise order starting top-left.
+type edges struct {
+	top, bottom, left, right                   image.Rectangle
+	topLeft, topRight, bottomRight, bottomLeft image.Rectangle
+}
+
+func edgeRegions(outer, inner image.Rectangle) edges {
+	return edges{
+		top:    image.Rect(inner.Min.X, outer.Min.Y, inner.Max.X, inner.Min.Y),
+		bottom: image.Rect(inner.Min.X, inner.Max.Y, inner.Max.X, outer.Max.Y),
+		left:   image.Rect(outer.Min.X, inner.Min.Y, inner.Min.X, inner.Max.Y),
+		right:  image.Rect(inner.Max.X, inner.Min.Y, outer.Max.X, inner.Max.Y),
+
+		topLeft:     image.Rect(outer.Min.X, outer.Min.Y, inner.Min.X, inner.Min.Y),
+		topRight:    image.Rect(inner.Max.X, outer.Min.Y, outer.Max.X, inner.Min.Y),
+		bottomRight: image.Rect(inner.Max.X, inner.Max.Y, outer.Max.X, outer.Max.Y),
+		bottomLeft:  image.Rect(outer.Min.X, inner.Max.Y, inner.Min.X, outer.Max.Y),
+	}
+}
+
+func fillRegions(dst *image.RGBA, c color.Color, e edges) {
+	u := &image.Uniform{C: c}
+	for _, r := range []image.Rectangle{e.top, e.bottom, e.left, e.right, e.topLeft, e.topRight, e.bottomRight, e.bottomLeft} {
+		draw.Draw(dst, r, u, image.Point{}, draw.Src)
+	}
+}
+
+func paintSample(dst *image.RGBA, src image.Image, inner image.Rectangle) {
+	e := edgeRegions(dst.Bounds(), inner)
+
+	top := medianEdgeColor(src, inner, sideTop)
+	bottom := medianEdgeColor(src, inner, sideBottom)
+	left := medianEdgeColor(src, inner, sideLeft)
+	right := medianEdgeColor(src, inner, sideRight)
+
+	draw.Draw(dst, e.top, &image.Uniform{C: top}, image.Point{}, draw.Src)
+	draw.Draw(dst, e.bottom, &image.Uniform{C: bottom}, image.Point{}, draw.Src)
+	draw.Draw(dst, e.left, &image.Uniform{C: left}, image.Point{}, draw.Src)
+	draw.Draw(dst, e.right, &image.Uniform{C: right}, image.Point{}, draw.Src)
+
+	draw.Draw(dst, e.topLeft, &image.Uniform{C: averageColor(top, left)}, image.Point{}, draw.Src)
+	draw.Draw(dst, e.topRight, &image.Uniform{C: averageColor(top, right)}, image.Point{}, draw.Src)
+	draw.Draw(dst, e.bottomRight, &image.Uniform{C: averageColor(bottom, right)}, image.Point{}, draw.Src)
+	draw.Draw(dst, e.bottomLeft, &image.Uniform{C: averageColor(bottom, left)}, image.Point{}, draw.Src)
+}
+
+type side int
+
+const (
+	sideTop side = iota
+	sideBottom
+	sideLeft
+	sideRight
+)
+
+// medianEdgeColor computes the per-channel median color of the outermost
+// sampleRingPx pixels along the given side of the card (in src's own
+// coordinate space, as bounded by inner).
+func medianEdgeColor(src image.Image, inner image.Rectangle, s side) color.RGBA {
+	var rs, gs, bs, as []uint32
+
+	add := func(x, y int) {
+		r, g, b, a := srcAt(src, inner, x, y).RGBA()
+		rs = append(rs, r)
+		gs = append(gs, g)
+		bs = append(bs, b)
+		as = append(as, a)
+	}
+
+	ring := sampleRingPx
+	switch s {
+	case sideTop:
+		for dy := 0; dy < ring && inner.Min.Y+dy < inner.Max.Y; dy++ {
+			for x := inner.Min.X; x < inner.Max.X; x++ {
+				add(x, inner.Min.Y+dy)
+			}
+		}
+	case sideBottom:
+		for dy := 0; dy < ring && inner.Max.Y-1-dy >= inner.Min.Y; dy++ {
+			for x := inner.Min.X; x < inner.Max.X; x++ {
+				add(x, inner.Max.Y-1-dy)
+			}
+		}
+	case sideLeft:
+		for dx := 0; dx < ring && inner.Min.X+dx < inner.Max.X; dx++ {
+			for y := inner.Min.Y; y < inner.Max.Y; y++ {
+				add(inner.Min.X+dx, y)
+			}
+		}
+	case sideRight:
+		for dx := 0; dx < ring && inner.Max.X-1-dx >= inner.Min.X; dx++ {
+			for y := inner.Min.Y; y < inner.Max.Y; y++ {
+				add(inner.Max.X-1-dx, y)
+			}
+		}
+	}
+
+	return color.RGBA{
+		R: uint8(median(rs) >> 8),
+		G: uint8(median(gs) >> 8),
+		B: uint8(median(bs) >> 8),
+		A: uint8(median(as) >> 8),
+	}
+}
+
+func median(vals []uint32) uint32 {
+	if len(vals) == 0 {
+		return 0
+	}
+	sorted := append([]uint32(nil), vals...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j-1] > sorted[j]; j-- {
+			sorted[j-1], sorted[j] = sorted[j], sorted[j-1]
+		}
+	}
+	return sorted[len(sorted)/2]
+}
+
+// srcAt reads src at the point corresponding to (x, y) in dst/inner
+// coordinates. inner is src's own bounds translated by the bleed width, so
+// the two coordinate spaces differ by a fixed offset.
+func srcAt(src image.Image, inner image.Rectangle, x, y int) color.Color {
+	off := inner.Min.Sub(src.Bounds().Min)
+	return src.At(x-off.X, y-off.Y)
+}
+
+func averageColor(a, b color.RGBA) color.RGBA {
+	return color.RGBA{
+		R: uint8((uint16(a.R) + uint16(b.R)) / 2),
+		G: uint8((uint16(a.G) + uint16(b.G)) / 2),
+		B: uint8((uint16(a.B) + uint16(b.B)) / 2),
+		A: uint8((uint16(a.A) + uint16(b.A)) / 2),
+	}
+}
+
+// paintMirror builds each bleed strip by reflecting the matching edge strip
+// of src across the card boundary, and fills each corner with a 2D
+// reflection of src's corner block.
+func paintMirror(dst *image.RGBA, src image.Image, inner image.Rectangle) {
+	bw := inner.Min.X - dst.Bounds().Min.X
+
+	for y := dst.Bounds().Min.Y; y < inner.Min.Y; y++ {
+		srcY := inner.Min.Y + (inner.Min.Y - 1 - y)
+		for x := inner.Min.X; x < inner.Max.X; x++ {
+			dst.Set(x, y, srcAt(src, inner, x, srcY))
+		}
+	}
+	for y := inner.Max.Y; y < dst.Bounds().Max.Y; y++ {
+		srcY := inner.Max.Y - 1 - (y - inner.Max.Y)
+		for x := inner.Min.X; x < inner.Max.X; x++ {
+			dst.Set(x, y, srcAt(src, inner, x, srcY))
+		}
+	}
+	for x := dst.Bounds().Min.X; x < inner.Min.X; x++ {
+		srcX := inner.Min.X + (inner.Min.X - 1 - x)
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			dst.Set(x, y, srcAt(src, inner, srcX, y))
+		}
+	}
+	for x := inner.Max.X; x < dst.Bounds().Max.X; x++ {
+		srcX := inner.Max.X - 1 - (x - inner.Max.X)
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			dst.Set(x, y, srcAt(src, inner, srcX, y))
+		}
+	}
+
+	mirrorCorner(dst, src, inner, image.Rect(dst.Bounds().Min.X, dst.Bounds().Min.Y, inner.Min.X, inner.Min.Y), inner.Min, bw, -1, -1)
+	mirrorCorner(dst, src, inner, image.Rect(inner.Max.X, dst.Bounds().Min.Y, dst.Bounds().Max.X, inner.Min.Y), image.Pt(inner.Max.X, inner.Min.Y), bw, 1, -1)
+	mirrorCorner(dst, src, inner, image.Rect(inner.Max.X, inner.Max.Y, dst.Bounds().Max.X, dst.Bounds().Max.Y), inner.Max, bw, 1, 1)
+	mirrorCorner(dst, src, inner, image.Rect(dst.Bounds().Min.X, inner.Max.Y, inner.Min.X, dst.Bounds().Max.Y), image.Pt(inner.Min.X, inner.Max.Y), bw, -1, 1)
+}
+
+// mirrorCorner fills the bw x bw corner square r with a point reflection of
+// the card's corner block through anchor, where xDir/yDir say which way the
+// corner square lies from the card (e.g. -1,-1 for the top-left corner).
+func mirrorCorner(dst *image.RGBA, src image.Image, inner image.Rectangle, r image.Rectangle, anchor image.Point, bw, xDir, yDir int) {
+	// The card pixel nearest the corner square; anchor is an exclusive
+	// bound on the sides where the square lies past inner.Max.
+	edgeX, edgeY := anchor.X, anchor.Y
+	if xDir > 0 {
+		edgeX--
+	}
+	if yDir > 0 {
+		edgeY--
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			distX := x - r.Min.X
+			distY := y - r.Min.Y
+			if xDir < 0 {
+				distX = r.Max.X - 1 - x
+			}
+			if yDir < 0 {
+				distY = r.Max.Y - 1 - y
+			}
+			srcX := edgeX - xDir*distX
+			srcY := edgeY - yDir*distY
+			dst.Set(x, y, srcAt(src, inner, srcX, srcY))
+		}
+	}
+}
+
+// paintExtend repeats the outermost row/column of src outward to fill the
+// bleed border.
+func paintExtend(dst *image.RGBA, src image.Image, inner image.Rectangle) {
+	for y := dst.Bounds().Min.Y; y < inner.Min.Y; y++ {
+		for x := inner.Min.X; x < inner.Max.X; x++ {
+			dst.Set(x, y, srcAt(src, inner, x, inner.Min.Y))
+		}
+	}
+	for y := inner.Max.Y; y < dst.Bounds().Max.Y; y++ {
+		for x := inner.Min.X; x < inner.Max.X; x++ {
+			dst.Set(x, y, srcAt(src, inner, x, inner.Max.Y-1))
+		}
+	}
+	for x := dst.Bounds().Min.X; x < inner.Min.X; x++ {
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			dst.Set(x, y, srcAt(src, inner, inner.Min.X, y))
+		}
+	}
+	for x := inner.Max.X; x < dst.Bounds().Max.X; x++ {
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			dst.Set(x, y, srcAt(src, inner, inner.Max.X-1, y))
+		}
+	}
+
+	corner := func(r image.Rectangle, cx, cy int) {
+		c := srcAt(src, inner, cx, cy)
+		draw.Draw(dst, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+	}
+	corner(image.Rect(dst.Bounds().Min.X, dst.Bounds().Min.Y, inner.Min.X, inner.Min.Y), inner.Min.X, inner.Min.Y)
+	corner(image.Rect(inner.Max.X, dst.Bounds().Min.Y, dst.Bounds().Max.X, inner.Min.Y), inner.Max.X-1, inner.Min.Y)
+	corner(image.Rect(inner.Max.X, inner.Max.Y, dst.Bounds().Max.X, dst.Bounds().Max.Y), inner.Max.X-1, inner.Max.Y-1)
+	corner(image.Rect(dst.Bounds().Min.X, inner.Max.Y, inner.Min.X, dst.Bounds().Max.Y), inner.Min.X, inner.Max.Y-1)
+}
+
+// CornerColor returns a single representative fill color for the named
+// corner under mode, for use by the small JPEG-artifact cleanup wedges
+// drawn near each corner (see fixCorners in the CLI package). Sample
+// averages the two adjacent sides; Mirror and Extend just use the card's
+// own corner pixel, since the cleanup wedge is too small to need a full
+// pixel-accurate reflection.
+func CornerColor(src image.Image, inner image.Rectangle, mode Mode, c Corner) color.Color {
+	switch mode {
+	case White:
+		return color.RGBA{255, 255, 255, 255}
+	case Sample:
+		switch c {
+		case TopLeft:
+			return averageColor(medianEdgeColor(src, inner, sideTop), medianEdgeColor(src, inner, sideLeft))
+		case TopRight:
+			return averageColor(medianEdgeColor(src, inner, sideTop), medianEdgeColor(src, inner, sideRight))
+		case BottomRight:
+			return averageColor(medianEdgeColor(src, inner, sideBottom), medianEdgeColor(src, inner, sideRight))
+		default:
+			return averageColor(medianEdgeColor(src, inner, sideBottom), medianEdgeColor(src, inner, sideLeft))
+		}
+	case Mirror, Extend:
+		switch c {
+		case TopLeft:
+			return srcAt(src, inner, inner.Min.X, inner.Min.Y)
+		case TopRight:
+			return srcAt(src, inner, inner.Max.X-1, inner.Min.Y)
+		case BottomRight:
+			return srcAt(src, inner, inner.Max.X-1, inner.Max.Y-1)
+		default:
+			return srcAt(src, inner, inner.Min.X, inner.Max.Y-1)
+		}
+	default:
+		return color.RGBA{0, 0, 0, 255}
+	}
+}
+
+// Corner names one of the four corners of a card for CornerColor.
+type Corner int
+
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomRight
+	BottomLeft
+)
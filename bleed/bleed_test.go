@@ -0,0 +1,112 @@
+package bleed
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func newTestCard(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw := &image.Uniform{C: c}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, draw.At(x, y))
+		}
+	}
+	return img
+}
+
+func withBleed(src image.Image, bw int) (*image.RGBA, image.Rectangle) {
+	b := src.Bounds()
+	outer := image.Rect(0, 0, b.Dx()+bw*2, b.Dy()+bw*2)
+	inner := b.Add(image.Point{bw, bw})
+	dst := image.NewRGBA(outer)
+	// Place the card itself into dst before painting the border.
+	for y := inner.Min.Y; y < inner.Max.Y; y++ {
+		for x := inner.Min.X; x < inner.Max.X; x++ {
+			dst.Set(x, y, src.At(x-bw, y-bw))
+		}
+	}
+	return dst, inner
+}
+
+func TestPaintBlack(t *testing.T) {
+	src := newTestCard(10, 10, color.RGBA{255, 0, 0, 255})
+	dst, inner := withBleed(src, 4)
+	Paint(dst, src, inner, Black)
+
+	if got := dst.At(0, 0); !colorEq(got, color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("top-left corner = %v, want black", got)
+	}
+	if got := dst.At(inner.Min.X, 0); !colorEq(got, color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("top strip = %v, want black", got)
+	}
+}
+
+func TestPaintWhite(t *testing.T) {
+	src := newTestCard(10, 10, color.RGBA{255, 0, 0, 255})
+	dst, inner := withBleed(src, 4)
+	Paint(dst, src, inner, White)
+
+	if got := dst.At(0, 0); !colorEq(got, color.RGBA{255, 255, 255, 255}) {
+		t.Fatalf("top-left corner = %v, want white", got)
+	}
+}
+
+func TestPaintSampleUniformCard(t *testing.T) {
+	// A solid-color card should produce a bleed border of the same color.
+	src := newTestCard(10, 10, color.RGBA{10, 20, 30, 255})
+	dst, inner := withBleed(src, 4)
+	Paint(dst, src, inner, Sample)
+
+	if got := dst.At(inner.Min.X, 0); !colorEq(got, color.RGBA{10, 20, 30, 255}) {
+		t.Fatalf("top strip = %v, want card color", got)
+	}
+	if got := dst.At(0, 0); !colorEq(got, color.RGBA{10, 20, 30, 255}) {
+		t.Fatalf("corner = %v, want card color", got)
+	}
+}
+
+func TestPaintMirrorReflectsEdge(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			src.Set(x, y, color.RGBA{uint8(y * 10), 0, 0, 255})
+		}
+	}
+	bw := 2
+	dst, inner := withBleed(src, bw)
+	Paint(dst, src, inner, Mirror)
+
+	// Row directly above the card (touching it) mirrors row 0 of the card.
+	got := dst.At(inner.Min.X, inner.Min.Y-1)
+	want := src.At(0, 0)
+	if !colorEq(got, want) {
+		t.Fatalf("row touching card = %v, want %v", got, want)
+	}
+	// The outermost bleed row mirrors row bw-1 of the card.
+	got = dst.At(inner.Min.X, 0)
+	want = src.At(0, bw-1)
+	if !colorEq(got, want) {
+		t.Fatalf("outermost bleed row = %v, want %v", got, want)
+	}
+}
+
+func TestPaintExtendRepeatsEdge(t *testing.T) {
+	src := newTestCard(6, 6, color.RGBA{5, 6, 7, 255})
+	dst, inner := withBleed(src, 3)
+	Paint(dst, src, inner, Extend)
+
+	for y := 0; y < inner.Min.Y; y++ {
+		if got := dst.At(inner.Min.X, y); !colorEq(got, color.RGBA{5, 6, 7, 255}) {
+			t.Fatalf("extend row %d = %v, want card edge color", y, got)
+		}
+	}
+}
+
+func colorEq(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// CardSize is a known physical card format, used by -autodpi to estimate
+// the source image's DPI from its pixel dimensions.
+type CardSize struct {
+	Name              string
+	WidthIn, HeightIn float64
+}
+
+var cardSizes = map[string]CardSize{
+	"mtg":   {Name: "mtg", WidthIn: 2.5, HeightIn: 3.5},
+	"poker": {Name: "poker", WidthIn: 2.5, HeightIn: 3.5},
+	"tarot": {Name: "tarot", WidthIn: 2.75, HeightIn: 4.75},
+	"mini":  {Name: "mini", WidthIn: 1.75, HeightIn: 2.5},
+}
+
+func parseCardSize(name string) (CardSize, error) {
+	size, ok := cardSizes[name]
+	if !ok {
+		return CardSize{}, fmt.Errorf("unknown card size %q, expected one of: mtg, poker, tarot, mini", name)
+	}
+	return size, nil
+}
+
+// dpiBleedIn is the bleed added per side when sizing from a DPI, in inches.
+const dpiBleedIn = 0.125
+
+// Legacy ratio-based sizing: assumes every card is widthNoBleedIn of art
+// and adds bleedWidthIn of bleed, regardless of the image's actual DPI.
+// heightNoBleedIn is the corresponding height assumption, used only to size
+// single-card PDF pages in legacy mode since bleedPx never needed it.
+const (
+	widthNoBleedIn  = 2.48
+	heightNoBleedIn = 3.48
+	bleedWidthIn    = 0.24
+)
+
+// BleedConfig controls how much bleed to add around a card. By default it
+// reproduces the original behavior of assuming every card is
+// NoBleedWidthIn of art at an unknown DPI and inferring the bleed from the
+// image's pixel width alone. Setting DPI or AutoDPI switches to sizing the
+// bleed directly from a known or detected DPI instead.
+type BleedConfig struct {
+	NoBleedWidthIn float64
+	BleedWidthIn   float64
+
+	DPI      int
+	AutoDPI  bool
+	CardSize CardSize
+}
+
+// bleedPx returns the bleed edge width in pixels for img, along with the
+// DPI used to compute it (for the per-image summary line).
+func (c BleedConfig) bleedPx(img image.Image) (px int, dpi float64) {
+	b := img.Bounds()
+
+	switch {
+	case c.AutoDPI:
+		dpi = detectDPI(b, c.CardSize)
+	case c.DPI > 0:
+		dpi = float64(c.DPI)
+	default:
+		return int(math.Round((float64(b.Dx())*c.BleedWidthIn)/c.NoBleedWidthIn)) / 2, float64(b.Dx()) / c.NoBleedWidthIn
+	}
+
+	return int(math.Round(dpi * dpiBleedIn)), dpi
+}
+
+// physicalSizeIn returns the card's physical width and height in inches
+// once bleed is added on all sides, used to size single-card PDF pages to
+// match whichever sizing mode bleedPx used. Print sheets don't use it: the
+// sheet packer sizes its grid from the bled card's pixel bounds.
+func (c BleedConfig) physicalSizeIn() (widthIn, heightIn float64) {
+	switch {
+	case c.AutoDPI, c.DPI > 0:
+		return c.CardSize.WidthIn + 2*dpiBleedIn, c.CardSize.HeightIn + 2*dpiBleedIn
+	default:
+		return widthNoBleedIn + bleedWidthIn, heightNoBleedIn + bleedWidthIn
+	}
+}
+
+// detectDPI estimates the source image's DPI from its longest pixel
+// dimension against the longest physical dimension of size.
+func detectDPI(b image.Rectangle, size CardSize) float64 {
+	longestPx := b.Dx()
+	if b.Dy() > longestPx {
+		longestPx = b.Dy()
+	}
+	longestIn := size.WidthIn
+	if size.HeightIn > longestIn {
+		longestIn = size.HeightIn
+	}
+	return float64(longestPx) / longestIn
+}
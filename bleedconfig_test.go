@@ -0,0 +1,48 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestBleedPxLegacyRatio(t *testing.T) {
+	cfg := BleedConfig{NoBleedWidthIn: widthNoBleedIn, BleedWidthIn: bleedWidthIn}
+	img := image.NewRGBA(image.Rect(0, 0, 750, 1050))
+	px, _ := cfg.bleedPx(img)
+	if px <= 0 {
+		t.Fatalf("bleedPx() = %d, want a positive bleed", px)
+	}
+}
+
+func TestBleedPxExplicitDPI(t *testing.T) {
+	cfg := BleedConfig{DPI: 300}
+	img := image.NewRGBA(image.Rect(0, 0, 750, 1050))
+	px, dpi := cfg.bleedPx(img)
+	if dpi != 300 {
+		t.Fatalf("dpi = %v, want 300", dpi)
+	}
+	want := 38 // round(300 * 0.125)
+	if px != want {
+		t.Fatalf("bleedPx() = %d, want %d", px, want)
+	}
+}
+
+func TestBleedPxAutoDPI(t *testing.T) {
+	size, err := parseCardSize("mtg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := BleedConfig{AutoDPI: true, CardSize: size}
+	// 3.5in longest side at 300dpi = 1050px.
+	img := image.NewRGBA(image.Rect(0, 0, 750, 1050))
+	_, dpi := cfg.bleedPx(img)
+	if dpi != 300 {
+		t.Fatalf("detected dpi = %v, want 300", dpi)
+	}
+}
+
+func TestParseCardSizeUnknown(t *testing.T) {
+	if _, err := parseCardSize("not-a-size"); err == nil {
+		t.Fatal("expected an error for an unknown card size")
+	}
+}
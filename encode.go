@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Metadata carries the physical sizing an Encoder needs beyond the raw
+// pixels: the page size a PDF should be created at, and (for PDF's vector
+// crop marks) how far the trim line sits inside that page. BleedIn of 0
+// means "don't draw crop marks" - e.g. a print sheet, where the marks are
+// already rasterized onto the image by the sheet package.
+type Metadata struct {
+	WidthIn, HeightIn float64
+	BleedIn           float64
+}
+
+// Encoder writes img to w in a specific file format.
+type Encoder interface {
+	Encode(w io.Writer, img image.Image, meta Metadata) error
+}
+
+func encoderFor(name string) (Encoder, error) {
+	switch name {
+	case "png":
+		return pngEncoder{}, nil
+	case "jpg", "jpeg":
+		return jpegEncoder{quality: 100}, nil
+	case "pdf":
+		return pdfEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", name)
+	}
+}
+
+type pngEncoder struct{}
+
+func (pngEncoder) Encode(w io.Writer, img image.Image, _ Metadata) error {
+	return png.Encode(w, img)
+}
+
+type jpegEncoder struct {
+	quality int
+}
+
+func (e jpegEncoder) Encode(w io.Writer, img image.Image, _ Metadata) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: e.quality})
+}
+
+// jpegXObjectQuality is the JPEG quality used when embedding a card as an
+// XObject in a PDF - high enough to look right at print size, without
+// ballooning the PDF with a lossless raster.
+const jpegXObjectQuality = 90
+
+type pdfEncoder struct{}
+
+func (pdfEncoder) Encode(w io.Writer, img image.Image, meta Metadata) error {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr: "in",
+		Size:    gofpdf.SizeType{Wd: meta.WidthIn, Ht: meta.HeightIn},
+	})
+	if err := addPDFPage(pdf, img, meta); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// addPDFPage adds one page to pdf sized to meta's page dimensions, with img
+// embedded as a JPEG XObject filling the page and, if meta.BleedIn > 0,
+// vector crop marks at the trim line.
+func addPDFPage(pdf *gofpdf.Fpdf, img image.Image, meta Metadata) error {
+	pdf.AddPage()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegXObjectQuality}); err != nil {
+		return err
+	}
+
+	imgName := fmt.Sprintf("card-%d", pdf.PageNo())
+	opt := gofpdf.ImageOptions{ImageType: "JPG"}
+	pdf.RegisterImageOptionsReader(imgName, opt, &buf)
+	pdf.ImageOptions(imgName, 0, 0, meta.WidthIn, meta.HeightIn, false, opt, 0, "")
+
+	if meta.BleedIn > 0 {
+		drawPDFCropMarks(pdf, meta)
+	}
+
+	if pdf.Err() {
+		return pdf.Error()
+	}
+	return nil
+}
+
+// pdfCropMarkLenIn/GapIn are the length of each trim tick and its gap from
+// the trim line, matching the proportions sheet.DefaultCropMarkStyle uses
+// for rasterized marks.
+const (
+	pdfCropMarkLenIn = 1.0 / 12
+	pdfCropMarkGapIn = 1.0 / 72
+)
+
+func drawPDFCropMarks(pdf *gofpdf.Fpdf, meta Metadata) {
+	trimMinX, trimMinY := meta.BleedIn, meta.BleedIn
+	trimMaxX, trimMaxY := meta.WidthIn-meta.BleedIn, meta.HeightIn-meta.BleedIn
+
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(0.01)
+
+	corner := func(x, y, xDir, yDir float64) {
+		pdf.Line(x+xDir*pdfCropMarkGapIn, y, x+xDir*(pdfCropMarkGapIn+pdfCropMarkLenIn), y)
+		pdf.Line(x, y+yDir*pdfCropMarkGapIn, x, y+yDir*(pdfCropMarkGapIn+pdfCropMarkLenIn))
+	}
+	corner(trimMinX, trimMinY, -1, -1)
+	corner(trimMaxX, trimMinY, 1, -1)
+	corner(trimMaxX, trimMaxY, 1, 1)
+	corner(trimMinX, trimMaxY, -1, 1)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidImg(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncoderForKnownFormats(t *testing.T) {
+	for _, name := range []string{"png", "jpg", "jpeg", "pdf"} {
+		if _, err := encoderFor(name); err != nil {
+			t.Errorf("encoderFor(%q) returned error: %v", name, err)
+		}
+	}
+}
+
+func TestEncoderForUnknownFormat(t *testing.T) {
+	if _, err := encoderFor("tiff"); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestPDFEncoderProducesPDF(t *testing.T) {
+	img := solidImg(10, 10, color.RGBA{200, 0, 0, 255})
+	meta := Metadata{WidthIn: 2.72, HeightIn: 3.72, BleedIn: 0.125}
+
+	var buf bytes.Buffer
+	if err := (pdfEncoder{}).Encode(&buf, img, meta); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("output doesn't start with a PDF header: %q", buf.Bytes()[:min(16, buf.Len())])
+	}
+}
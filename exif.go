@@ -0,0 +1,110 @@
+package main
+
+import (
+	"image"
+	"io"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// readOrientation reads the EXIF Orientation tag from r, returning 1 (no
+// transform needed) if there's no EXIF segment or Orientation tag. The
+// caller is responsible for seeking back to the start of the file
+// afterward, since exif.Decode consumes the reader.
+func readOrientation(r io.Reader) int {
+	x, err := exif.Decode(r)
+	if err != nil {
+		return 1
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	o, err := tag.Int(0)
+	if err != nil || o < 1 || o > 8 {
+		return 1
+	}
+	return o
+}
+
+// applyOrientation rotates/flips img so its pixels match what the EXIF
+// Orientation tag says it should look like. Orientation 1 (or anything
+// outside the defined 1-8 range) is returned unchanged.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flipH(img)
+	case 3:
+		return rotate180(img)
+	case 4:
+		return flipV(img)
+	case 5:
+		return rotate270CW(flipH(img))
+	case 6:
+		return rotate90CW(img)
+	case 7:
+		return rotate90CW(flipH(img))
+	case 8:
+		return rotate270CW(img)
+	default:
+		return img
+	}
+}
+
+func flipH(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(b.Dx()-1-x, y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+func flipV(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(x, b.Dy()-1-y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+func rotate180(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(b.Dx()-1-x, b.Dy()-1-y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// rotate90CW rotates img 90 degrees clockwise, swapping its dimensions.
+func rotate90CW(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(b.Dy()-1-y, x, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// rotate270CW rotates img 270 degrees clockwise (90 counter-clockwise),
+// swapping its dimensions.
+func rotate270CW(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(y, b.Dx()-1-x, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
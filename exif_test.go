@@ -0,0 +1,122 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// tagged builds a 2x1 image so rotations/flips are easy to eyeball: the
+// left pixel is red, the right pixel is blue.
+func tagged() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{0, 0, 255, 255})
+	return img
+}
+
+func TestApplyOrientationIdentity(t *testing.T) {
+	img := tagged()
+	out := applyOrientation(img, 1)
+	if out != image.Image(img) {
+		t.Fatalf("orientation 1 should return img unchanged")
+	}
+}
+
+func TestApplyOrientationFlipH(t *testing.T) {
+	out := applyOrientation(tagged(), 2)
+	if !sameColor(out.At(0, 0), color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("flipH: (0,0) = %v, want blue", out.At(0, 0))
+	}
+}
+
+func TestApplyOrientationRotate180(t *testing.T) {
+	out := applyOrientation(tagged(), 3)
+	if !sameColor(out.At(0, 0), color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("rotate180: (0,0) = %v, want blue", out.At(0, 0))
+	}
+	if !sameColor(out.At(1, 0), color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("rotate180: (1,0) = %v, want red", out.At(1, 0))
+	}
+}
+
+// taggedV builds a 1x2 image for exercising vertical flips: the top pixel
+// is red, the bottom pixel is blue.
+func taggedV() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(0, 1, color.RGBA{0, 0, 255, 255})
+	return img
+}
+
+func TestApplyOrientationFlipV(t *testing.T) {
+	out := applyOrientation(taggedV(), 4)
+	if !sameColor(out.At(0, 0), color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("flipV: (0,0) = %v, want blue", out.At(0, 0))
+	}
+	if !sameColor(out.At(0, 1), color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("flipV: (0,1) = %v, want red", out.At(0, 1))
+	}
+}
+
+func TestApplyOrientationRotate90CW(t *testing.T) {
+	out := applyOrientation(tagged(), 6)
+	b := out.Bounds()
+	if b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("rotate90CW bounds = %v, want 1x2", b)
+	}
+	// The red (left) pixel should end up on top after a 90deg CW turn.
+	if !sameColor(out.At(0, 0), color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("rotate90CW: (0,0) = %v, want red", out.At(0, 0))
+	}
+}
+
+func TestApplyOrientationMirrorRotate270CW(t *testing.T) {
+	// Orientation 5: mirror horizontal, then rotate 270 CW.
+	out := applyOrientation(tagged(), 5)
+	b := out.Bounds()
+	if b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("orientation 5 bounds = %v, want 1x2", b)
+	}
+	if !sameColor(out.At(0, 0), color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("orientation 5: (0,0) = %v, want red", out.At(0, 0))
+	}
+	if !sameColor(out.At(0, 1), color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("orientation 5: (0,1) = %v, want blue", out.At(0, 1))
+	}
+}
+
+func TestApplyOrientationMirrorRotate90CW(t *testing.T) {
+	// Orientation 7: mirror horizontal, then rotate 90 CW.
+	out := applyOrientation(tagged(), 7)
+	b := out.Bounds()
+	if b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("orientation 7 bounds = %v, want 1x2", b)
+	}
+	if !sameColor(out.At(0, 0), color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("orientation 7: (0,0) = %v, want blue", out.At(0, 0))
+	}
+	if !sameColor(out.At(0, 1), color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("orientation 7: (0,1) = %v, want red", out.At(0, 1))
+	}
+}
+
+func TestApplyOrientationRotate270CW(t *testing.T) {
+	out := applyOrientation(tagged(), 8)
+	b := out.Bounds()
+	if b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("rotate270CW bounds = %v, want 1x2", b)
+	}
+	if !sameColor(out.At(0, 0), color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("rotate270CW: (0,0) = %v, want blue", out.At(0, 0))
+	}
+	if !sameColor(out.At(0, 1), color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("rotate270CW: (0,1) = %v, want red", out.At(0, 1))
+	}
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
@@ -5,25 +5,19 @@ import (
 	"flag"
 	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
-	"image/jpeg"
-	"image/png"
+	"io"
 	"io/fs"
-	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
-)
 
-var (
-	// dpiBleedRatio  = 50.0 / 12.0
-	// widthPlusBleed = 2.72
-	widthNoBleed = 2.48
-	bleedWidth   = 0.24
+	"github.com/Solidsilver/mpc-bleeder/bleed"
+	"github.com/Solidsilver/mpc-bleeder/sheet"
+	"github.com/jung-kurt/gofpdf"
 )
 
 var (
@@ -33,22 +27,66 @@ var (
 
 var (
 	OW_OUT         = flag.Bool("w", false, "Overwrite output images with the same name that already exist.")
-	OUT_FMT        = flag.String("fmt", "png", "Output format of cards. Supports [png, jpg, auto]. Auto keeps output format the same as input.")
+	OUT_FMT        = flag.String("fmt", "png", "Output format of cards. Supports [png, jpg, pdf, auto]. Auto keeps output format the same as input. pdf embeds each card as a JPEG XObject on a page sized to its physical dimensions, with vector crop marks.")
 	JPG_CORNER_FIX = flag.Bool("jcf", false, "Jpg Corner Fix - removes white artifacts from the corners of jpeg cards")
+	BLEED_MODE     = flag.String("bleed", "black", "Bleed fill mode. Supports [black, white, sample, mirror, extend].")
+	SHEET_SIZE     = flag.String("sheet", "", "Pack bled cards onto a print sheet instead of writing one file per card. Supports [letter, a4].")
+	DPI            = flag.Int("dpi", 300, "DPI of the input images. When given explicitly and -autodpi is unset, also sizes the bleed directly (bleed = dpi*0.125in). Always used to lay out print sheets with -sheet.")
+	SHEET_GUTTER   = flag.Float64("gutter", 0, "Gutter between cards on a print sheet, in inches.")
+	NO_EXIF        = flag.Bool("noexif", false, "Disable EXIF orientation normalization before adding bleed.")
+	AUTO_DPI       = flag.Bool("autodpi", false, "Estimate each image's DPI from its pixel dimensions against -size, instead of a fixed DPI or bleed ratio.")
+	CARD_SIZE      = flag.String("size", "mtg", "Physical card size for -autodpi. Supports [mtg, poker, tarot, mini].")
 )
 
-var (
-	BLACK = color.RGBA{0, 0, 0, 255}
-	RED   = color.RGBA{255, 0, 0, 255}
-	GREEN = color.RGBA{0, 255, 0, 255}
-	BLUE  = color.RGBA{0, 0, 255, 255}
-)
+// bleedMode is the parsed, validated form of BLEED_MODE, set in main().
+var bleedMode bleed.Mode
+
+// bleedConfig is the resolved form of the -dpi/-autodpi/-size flags, set in
+// main().
+var bleedConfig BleedConfig
 
 func main() {
 	inFileFolder := flag.String("i", ".", "File or folder to read in from")
 	outFolder := flag.String("out", "./bleeder_out", "Folder to output to")
 	flag.Parse()
 
+	mode, err := bleed.ParseMode(*BLEED_MODE)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	bleedMode = mode
+
+	cardSize, err := parseCardSize(*CARD_SIZE)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	dpiSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "dpi" {
+			dpiSet = true
+		}
+	})
+	bleedConfig = BleedConfig{
+		NoBleedWidthIn: widthNoBleedIn,
+		BleedWidthIn:   bleedWidthIn,
+		AutoDPI:        *AUTO_DPI,
+		CardSize:       cardSize,
+	}
+	if dpiSet {
+		bleedConfig.DPI = *DPI
+	}
+
+	var sheetAsm *sheetAssembler
+	if *SHEET_SIZE != "" {
+		sheetAsm, err = newSheetAssembler(*SHEET_SIZE, *outFolder)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
 	if err := os.MkdirAll(*outFolder, 0o700); err != nil {
 		fmt.Printf("Failed to create output directory: %s\n", err.Error())
 		os.Exit(1)
@@ -66,12 +104,19 @@ func main() {
 		go queueImageJobs(*inFileFolder, entries, jobs)
 		for range 8 {
 			wg.Add(1)
-			go handleWriteImageWorker(jobs, *outFolder, &wg)
+			go handleWriteImageWorker(jobs, *outFolder, sheetAsm, &wg)
 		}
 		wg.Wait()
 	} else {
 		// input path is not directory
-		handleWriteImage(*inFileFolder, *outFolder)
+		handleWriteImage(*inFileFolder, *outFolder, sheetAsm)
+	}
+
+	if sheetAsm != nil {
+		if err := sheetAsm.finish(); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
 	}
 	fmt.Printf("Done! See results in %s\n", *outFolder)
 }
@@ -86,10 +131,10 @@ func queueImageJobs(inFolder string, entries []fs.DirEntry, jobs chan string) {
 	close(jobs)
 }
 
-func handleWriteImageWorker(jobs <-chan string, outFolderPath string, wg *sync.WaitGroup) {
+func handleWriteImageWorker(jobs <-chan string, outFolderPath string, sheetAsm *sheetAssembler, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for inFilePath := range jobs {
-		err := handleWriteImage(inFilePath, outFolderPath)
+		err := handleWriteImage(inFilePath, outFolderPath, sheetAsm)
 		if err != nil {
 			err = errors.Join(fmt.Errorf("failed to read image file from %s", inFilePath), err)
 			fmt.Println(err.Error())
@@ -97,122 +142,221 @@ func handleWriteImageWorker(jobs <-chan string, outFolderPath string, wg *sync.W
 	}
 }
 
-func handleWriteImage(inFilePath, outFolderPath string) error {
+func handleWriteImage(inFilePath, outFolderPath string, sheetAsm *sheetAssembler) error {
 	inFile, err := os.Open(inFilePath)
 	if err != nil {
 		return errors.Join(fmt.Errorf("failed to open image file [%s]", inFilePath), err)
 	}
 
+	orientation := 1
+	if !*NO_EXIF {
+		orientation = readOrientation(inFile)
+		if _, err := inFile.Seek(0, io.SeekStart); err != nil {
+			return errors.Join(fmt.Errorf("failed to read image file from %s", inFilePath), err)
+		}
+	}
+
 	img, newImgFormat, err := image.Decode(inFile)
 	if err != nil {
 		return err
 	}
-	// Need to re-write. Output file needs new name
-	newImg := createCardWithBleed(img, newImgFormat)
+	if orientation != 1 {
+		img = applyOrientation(img, orientation)
+	}
+	// Need to re-write. Output file needs new name. The EXIF segment isn't
+	// carried over to the saved image, so downstream viewers won't
+	// re-apply the orientation we already normalized away.
 	outImgBaseName := path.Base(inFile.Name())
+	newImg := createCardWithBleed(img, newImgFormat, outImgBaseName, bleedConfig)
+
+	if sheetAsm != nil {
+		return sheetAsm.add(newImg)
+	}
+
 	outImgPath := path.Join(outFolderPath, outImgBaseName)
 	if _, err := os.Stat(outImgPath); *OW_OUT && !errors.Is(err, os.ErrNotExist) {
 		fmt.Printf("Output file exists, skipping: [%s]\n", outImgBaseName)
 		return nil
 	}
-	fmt.Printf("Adding bleed to image: [%s]\n", outImgBaseName)
 
-	return saveImage(outImgPath, newImg, newImgFormat)
+	bleedEdgePx, dpi := bleedConfig.bleedPx(img)
+	cardWidthIn, cardHeightIn := bleedConfig.physicalSizeIn()
+	meta := Metadata{
+		WidthIn:  cardWidthIn,
+		HeightIn: cardHeightIn,
+		BleedIn:  float64(bleedEdgePx) / dpi,
+	}
+	return saveImage(outImgPath, newImg, newImgFormat, meta)
 }
 
-func saveImage(outPath string, imgOut image.Image, imgInFmt string) error {
+func saveImage(outPath string, imgOut image.Image, imgInFmt string, meta Metadata) error {
 	imgOutFmt := imgInFmt
 	if *OUT_FMT != "auto" {
 		imgOutFmt = *OUT_FMT
 	}
+	enc, err := encoderFor(imgOutFmt)
+	if err != nil {
+		return fmt.Errorf("Failed to save image - %w", err)
+	}
+
 	outPath = strings.TrimSuffix(outPath, filepath.Ext(outPath)) + "." + imgOutFmt
 	imgOutFile, err := os.Create(outPath)
 	if err != nil {
 		return errors.Join(fmt.Errorf("Failed to create file: [%s]\n", outPath), err)
 	}
+	defer imgOutFile.Close()
 
-	switch imgOutFmt {
-	case "png":
-		return png.Encode(imgOutFile, imgOut)
-	case "jpg", "jpeg":
-		return jpeg.Encode(imgOutFile, imgOut, &jpeg.Options{Quality: 100})
+	return enc.Encode(imgOutFile, imgOut, meta)
+}
+
+// sheetAssembler packs bled cards arriving from the worker pool onto print
+// sheets instead of writing one file per card, flushing each sheet to disk
+// as soon as it fills up. When the output format is pdf, pages accumulate
+// into a single multi-page document instead of one file per sheet.
+type sheetAssembler struct {
+	outFolder string
+	format    string
+	paper     sheet.PaperSize
+	packer    *sheet.Packer
+
+	mu     sync.Mutex
+	pdfDoc *gofpdf.Fpdf
+}
+
+func newSheetAssembler(sizeName, outFolder string) (*sheetAssembler, error) {
+	paper, err := sheet.ParsePaperSize(sizeName)
+	if err != nil {
+		return nil, err
+	}
+	marks := sheet.DefaultCropMarkStyle(*DPI)
+	packer := sheet.NewPacker(paper, *DPI, *SHEET_GUTTER, marks)
+
+	format := *OUT_FMT
+	if format == "auto" {
+		format = "png"
+	}
+	return &sheetAssembler{outFolder: outFolder, format: format, paper: paper, packer: packer}, nil
+}
+
+// add queues a bled card, writing out a sheet file if doing so fills a page.
+func (s *sheetAssembler) add(card image.Image) error {
+	s.mu.Lock()
+	page := s.packer.Add(card)
+	s.mu.Unlock()
+
+	if page == nil {
+		return nil
+	}
+	return s.savePage(page)
+}
+
+// finish flushes any partially-filled page once all cards have been queued,
+// and, in pdf mode, writes out the accumulated multi-page document.
+func (s *sheetAssembler) finish() error {
+	s.mu.Lock()
+	page := s.packer.Flush()
+	s.mu.Unlock()
+
+	if page != nil {
+		if err := s.savePage(page); err != nil {
+			return err
+		}
+	}
+
+	if s.pdfDoc == nil {
+		return nil
 	}
-	return fmt.Errorf("Failed to save image - unsupported format: %s", imgOutFmt)
+	outPath := path.Join(s.outFolder, "sheets.pdf")
+	fmt.Printf("Writing sheet PDF: [%s]\n", path.Base(outPath))
+	f, err := os.Create(outPath)
+	if err != nil {
+		return errors.Join(fmt.Errorf("Failed to create file: [%s]\n", outPath), err)
+	}
+	defer f.Close()
+	return s.pdfDoc.Output(f)
+}
+
+func (s *sheetAssembler) savePage(page *sheet.Page) error {
+	if s.format == "pdf" {
+		return s.addPDFPage(page)
+	}
+	outPath := path.Join(s.outFolder, fmt.Sprintf("sheet_%04d.%s", page.Index, s.format))
+	fmt.Printf("Writing sheet: [%s]\n", path.Base(outPath))
+	return saveImage(outPath, page.Img, s.format, Metadata{})
 }
 
-func createCardWithBleed(cardImg image.Image, inImgFormat string) image.Image {
-	// cardWithBleedBounds, cardNoBleedBounds := calculateBleedBounds(cardImg)
-	bleedEdgePx := calculateBleedWidth(cardImg)
+// addPDFPage adds page as one page of the assembler's shared multi-page PDF
+// document. The page image already has crop marks rasterized onto it by the
+// sheet package, so no vector marks are drawn here (BleedIn stays 0).
+//
+// gofpdf.Fpdf isn't safe for concurrent use, and savePage can be called
+// from multiple worker goroutines, so the whole read-modify-write of
+// s.pdfDoc is done under s.mu rather than just the packer calls.
+func (s *sheetAssembler) addPDFPage(page *sheet.Page) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pdfDoc == nil {
+		s.pdfDoc = gofpdf.NewCustom(&gofpdf.InitType{
+			UnitStr: "in",
+			Size:    gofpdf.SizeType{Wd: s.paper.WidthIn, Ht: s.paper.HeightIn},
+		})
+	}
+	fmt.Printf("Adding sheet page %d to sheets.pdf\n", page.Index)
+	return addPDFPage(s.pdfDoc, page.Img, Metadata{WidthIn: s.paper.WidthIn, HeightIn: s.paper.HeightIn})
+}
+
+func createCardWithBleed(cardImg image.Image, inImgFormat, fileName string, cfg BleedConfig) image.Image {
+	bleedEdgePx, dpi := cfg.bleedPx(cardImg)
+	b := cardImg.Bounds()
+	fmt.Printf("%s: %dx%d @ %.0fdpi -> %dpx bleed\n", fileName, b.Dx(), b.Dy(), dpi, bleedEdgePx)
 
 	cardWithBleedBounds := image.Rect(0, 0, cardImg.Bounds().Dx()+bleedEdgePx*2, cardImg.Bounds().Dy()+bleedEdgePx*2)
-	// cardNoBleedBounds := image.Rect(bleedEdgePx, bleedEdgePx, cardImg.Bounds().Dx()+bleedEdgePx, cardWithBleedBounds.Dy()+bleedEdgePx)
 	cardNoBleedBounds := cardImg.Bounds().Add(image.Point{bleedEdgePx, bleedEdgePx})
 
 	cardWithBleed := image.NewRGBA(cardWithBleedBounds)
 
-	// Draw black background
-	draw.Draw(cardWithBleed, cardWithBleedBounds, &image.Uniform{C: BLACK}, image.Point{}, draw.Src)
-	// Draw card onto image
+	// Draw card onto image, then paint the surrounding bleed border
 	draw.Draw(cardWithBleed, cardNoBleedBounds, cardImg, image.Point{}, draw.Over)
+	bleed.Paint(cardWithBleed, cardImg, cardNoBleedBounds, bleedMode)
 
 	if cornerFix && (inImgFormat == "jpg" || inImgFormat == "jpeg") {
-		fixCorners(cardWithBleed, bleedEdgePx, cardNoBleedBounds)
+		fixCorners(cardWithBleed, cardImg, bleedEdgePx, cardNoBleedBounds, bleedMode)
 	}
 
 	return cardWithBleed
 }
 
 // Overwrites the corners of the image to potentially fix issue if previously created with PNG
-func fixCorners(img *image.RGBA, bleedWidth int, innerCardBounds image.Rectangle) {
+func fixCorners(img *image.RGBA, cardImg image.Image, bleedWidth int, innerCardBounds image.Rectangle, mode bleed.Mode) {
 	// bleedWidth := (cardWithBleedBounds.Dx() - innerCardBounds.Dx()) / 2 // Maybe pass this in instead of calculating
 	rectWidth := int(float64(bleedWidth) * 0.75)
 
-	blackImg := &image.Uniform{C: BLACK}
+	fillFor := func(c bleed.Corner) *image.Uniform {
+		return &image.Uniform{C: bleed.CornerColor(cardImg, innerCardBounds, mode, c)}
+	}
 
 	// Top left
 	origin := innerCardBounds.Min
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+rectWidth, origin.Y+bleedWidth), blackImg, image.Point{}, draw.Over)
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+bleedWidth, origin.Y+rectWidth), blackImg, image.Point{}, draw.Over)
+	fillImg := fillFor(bleed.TopLeft)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+rectWidth, origin.Y+bleedWidth), fillImg, image.Point{}, draw.Over)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+bleedWidth, origin.Y+rectWidth), fillImg, image.Point{}, draw.Over)
 
 	// Top right
 	origin.X = innerCardBounds.Max.X
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-rectWidth, origin.Y+bleedWidth), blackImg, image.Point{}, draw.Over)
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-bleedWidth, origin.Y+rectWidth), blackImg, image.Point{}, draw.Over)
+	fillImg = fillFor(bleed.TopRight)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-rectWidth, origin.Y+bleedWidth), fillImg, image.Point{}, draw.Over)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-bleedWidth, origin.Y+rectWidth), fillImg, image.Point{}, draw.Over)
 
 	// Bottom right
 	origin.Y = innerCardBounds.Max.Y
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-rectWidth, origin.Y-bleedWidth), blackImg, image.Point{}, draw.Over)
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-bleedWidth, origin.Y-rectWidth), blackImg, image.Point{}, draw.Over)
+	fillImg = fillFor(bleed.BottomRight)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-rectWidth, origin.Y-bleedWidth), fillImg, image.Point{}, draw.Over)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X-bleedWidth, origin.Y-rectWidth), fillImg, image.Point{}, draw.Over)
 
 	// Bottom left
 	origin.X = innerCardBounds.Min.X
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+rectWidth, origin.Y-bleedWidth), blackImg, image.Point{}, draw.Over)
-	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+bleedWidth, origin.Y-rectWidth), blackImg, image.Point{}, draw.Over)
-	// startPoint.Y += origCardBounds.Dy()
-	// draw.Draw(img, image.Rect(0, 0, bleedWidth, bleedWidth), blackImg, startPoint, draw.Over)
-}
-
-func calculateBleedBounds(img image.Image) (cardWithBleedBounds image.Rectangle, origCardBounds image.Rectangle) {
-	imgBounds := img.Bounds()
-	cardWidth := imgBounds.Dx()
-	cardHeight := imgBounds.Dy()
-
-	bleedEdgeWidthPx := int(math.Round((float64(cardWidth)*bleedWidth)/widthNoBleed)) / 2
-
-	// dpi := float64(bleedEdge) / bleedWidth
-
-	// fmt.Printf("Card bleed: %dpx, dpi: %f\n", bleedEdge, dpi)
-
-	newCardWidth := cardWidth + bleedEdgeWidthPx*2
-	newCardHeight := cardHeight + bleedEdgeWidthPx*2
-	cardWithBleedBounds = image.Rect(0, 0, newCardWidth, newCardHeight)
-	origCardBounds = image.Rect(bleedEdgeWidthPx, bleedEdgeWidthPx, newCardWidth-bleedEdgeWidthPx, newCardHeight-bleedEdgeWidthPx)
-	return cardWithBleedBounds, origCardBounds
-}
-
-func calculateBleedWidth(img image.Image) int {
-	imgBounds := img.Bounds()
-	cardWidth := imgBounds.Dx()
-
-	return int(math.Round((float64(cardWidth)*bleedWidth)/widthNoBleed)) / 2
+	fillImg = fillFor(bleed.BottomLeft)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+rectWidth, origin.Y-bleedWidth), fillImg, image.Point{}, draw.Over)
+	draw.Draw(img, image.Rect(origin.X, origin.Y, origin.X+bleedWidth, origin.Y-rectWidth), fillImg, image.Point{}, draw.Over)
 }
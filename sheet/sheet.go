@@ -0,0 +1,216 @@
+// Package sheet packs bled card images onto print-ready sheets (e.g. US
+// Letter or A4), drawing crop marks between cards so a sheet of cards can
+// be cut apart cleanly.
+package sheet
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// PaperSize is a physical page size in inches.
+type PaperSize struct {
+	Name              string
+	WidthIn, HeightIn float64
+}
+
+var (
+	Letter = PaperSize{Name: "letter", WidthIn: 8.5, HeightIn: 11}
+	A4     = PaperSize{Name: "a4", WidthIn: 8.27, HeightIn: 11.69}
+)
+
+// ParsePaperSize resolves a `-sheet` flag value to a PaperSize.
+func ParsePaperSize(s string) (PaperSize, error) {
+	switch s {
+	case Letter.Name:
+		return Letter, nil
+	case A4.Name:
+		return A4, nil
+	default:
+		return PaperSize{}, fmt.Errorf("unknown sheet size %q, expected one of: letter, a4", s)
+	}
+}
+
+// CropMarkStyle controls how the trim ticks between cards are drawn.
+type CropMarkStyle struct {
+	LengthPx int
+	GapPx    int
+	Color    color.Color
+}
+
+// DefaultCropMarkStyle returns thin black ticks scaled for the given DPI.
+func DefaultCropMarkStyle(dpi int) CropMarkStyle {
+	return CropMarkStyle{
+		LengthPx: dpi / 12, // 1/12th of an inch
+		GapPx:    dpi / 72, // 1/72nd of an inch
+		Color:    color.Black,
+	}
+}
+
+// Page is one assembled sheet, ready to be encoded and written out.
+type Page struct {
+	Index int
+	Img   *image.RGBA
+}
+
+// Packer accumulates bled cards and groups them into Pages sized to fit a
+// paper size at a given DPI. The per-card pixel size isn't known until the
+// first card arrives - it depends on how that card was bled, not on the
+// paper's DPI alone - so the grid is sized from the first card passed to
+// Add rather than from a nominal physical size.
+type Packer struct {
+	paper    PaperSize
+	dpi      int
+	marks    CropMarkStyle
+	gutterIn float64
+	gutterPx int
+	paperPx  image.Point
+
+	cardSize image.Point // zero until the first Add call sizes the grid
+	cols     int
+	rows     int
+
+	pending   []image.Image
+	pageIndex int
+}
+
+// NewPacker builds a Packer for the given paper size, DPI and gutter
+// between cards (in inches). The grid is sized once Add receives its
+// first card.
+func NewPacker(paper PaperSize, dpi int, gutterIn float64, marks CropMarkStyle) *Packer {
+	paperPx := image.Pt(int(paper.WidthIn*float64(dpi)), int(paper.HeightIn*float64(dpi)))
+
+	return &Packer{
+		paper:    paper,
+		dpi:      dpi,
+		marks:    marks,
+		gutterIn: gutterIn,
+		gutterPx: int(gutterIn * float64(dpi)),
+		paperPx:  paperPx,
+	}
+}
+
+// Capacity is how many cards fit on one page. It's 0 until the first card
+// has been added and the grid is sized.
+func (p *Packer) Capacity() int {
+	return p.cols * p.rows
+}
+
+// sizeGrid sizes the card slot and grid from an actual bled card's pixel
+// bounds, since the nominal physical card size (inches * DPI) doesn't
+// match what bleedPx actually produced for legacy/autodpi-sized cards.
+func (p *Packer) sizeGrid(cardSize image.Point) {
+	p.cardSize = cardSize
+
+	cols := (p.paperPx.X + p.gutterPx) / (cardSize.X + p.gutterPx)
+	rows := (p.paperPx.Y + p.gutterPx) / (cardSize.Y + p.gutterPx)
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	p.cols, p.rows = cols, rows
+}
+
+// Add queues a bled card for placement. When doing so fills the current
+// page, the completed Page is returned so the caller can write it out;
+// otherwise Add returns nil and keeps buffering.
+func (p *Packer) Add(card image.Image) *Page {
+	if p.cardSize == (image.Point{}) {
+		p.sizeGrid(card.Bounds().Size())
+	}
+	p.pending = append(p.pending, card)
+	if len(p.pending) == p.Capacity() {
+		return p.render()
+	}
+	return nil
+}
+
+// Flush finalizes a partially-filled page, centering the leftover cards.
+// Returns nil if there's nothing pending.
+func (p *Packer) Flush() *Page {
+	if len(p.pending) == 0 {
+		return nil
+	}
+	return p.render()
+}
+
+func (p *Packer) render() *Page {
+	n := len(p.pending)
+	rowsUsed := (n + p.cols - 1) / p.cols
+
+	gridW := p.cols*p.cardSize.X + (p.cols-1)*p.gutterPx
+	gridH := rowsUsed*p.cardSize.Y + (rowsUsed-1)*p.gutterPx
+	offsetX := (p.paperPx.X - gridW) / 2
+	offsetY := (p.paperPx.Y - gridH) / 2
+
+	img := image.NewRGBA(image.Rect(0, 0, p.paperPx.X, p.paperPx.Y))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+
+	for i, card := range p.pending {
+		row := i / p.cols
+		col := i % p.cols
+
+		// Center a short final row within the grid width.
+		colsInRow := p.cols
+		if row == rowsUsed-1 {
+			colsInRow = n - row*p.cols
+		}
+		rowOffsetX := offsetX + (gridW-(colsInRow*p.cardSize.X+(colsInRow-1)*p.gutterPx))/2
+
+		x := rowOffsetX + col*(p.cardSize.X+p.gutterPx)
+		y := offsetY + row*(p.cardSize.Y+p.gutterPx)
+		r := image.Rect(x, y, x+p.cardSize.X, y+p.cardSize.Y)
+
+		// Cards are normally all the same size (the one the grid was
+		// sized from), but center instead of pinning to the corner in
+		// case a card's actual size ever drifts from it.
+		draw.Draw(img, centered(card.Bounds().Size(), r), card, card.Bounds().Min, draw.Src)
+		drawCropMarks(img, r, p.marks)
+	}
+
+	page := &Page{Index: p.pageIndex + 1, Img: img}
+	p.pageIndex++
+	p.pending = nil
+	return page
+}
+
+// centered returns the rectangle of size sz centered within r.
+func centered(sz image.Point, r image.Rectangle) image.Rectangle {
+	x := r.Min.X + (r.Dx()-sz.X)/2
+	y := r.Min.Y + (r.Dy()-sz.Y)/2
+	return image.Rect(x, y, x+sz.X, y+sz.Y)
+}
+
+// drawCropMarks draws a short L-shaped tick just outside each corner of r.
+func drawCropMarks(img *image.RGBA, r image.Rectangle, style CropMarkStyle) {
+	bounds := img.Bounds()
+	u := &image.Uniform{C: style.Color}
+
+	hTick := func(x0, x1, y int) {
+		tr := image.Rect(x0, y, x1, y+1).Intersect(bounds)
+		draw.Draw(img, tr, u, image.Point{}, draw.Src)
+	}
+	vTick := func(x, y0, y1 int) {
+		tr := image.Rect(x, y0, x+1, y1).Intersect(bounds)
+		draw.Draw(img, tr, u, image.Point{}, draw.Src)
+	}
+
+	g, l := style.GapPx, style.LengthPx
+
+	// Top-left
+	hTick(r.Min.X-g-l, r.Min.X-g, r.Min.Y)
+	vTick(r.Min.X, r.Min.Y-g-l, r.Min.Y-g)
+	// Top-right
+	hTick(r.Max.X+g, r.Max.X+g+l, r.Min.Y)
+	vTick(r.Max.X, r.Min.Y-g-l, r.Min.Y-g)
+	// Bottom-right
+	hTick(r.Max.X+g, r.Max.X+g+l, r.Max.Y)
+	vTick(r.Max.X, r.Max.Y+g, r.Max.Y+g+l)
+	// Bottom-left
+	hTick(r.Min.X-g-l, r.Min.X-g, r.Max.Y)
+	vTick(r.Min.X, r.Max.Y+g, r.Max.Y+g+l)
+}
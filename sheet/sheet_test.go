@@ -0,0 +1,82 @@
+package sheet
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidCard(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw := &image.Uniform{C: c}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, draw.At(x, y))
+		}
+	}
+	return img
+}
+
+// cardPx is a 2.72x3.72in bled card at 300dpi (816x1116px), used as the
+// first card so the grid sizes the way the old inches-based constructor
+// used to.
+func cardPx() image.Image {
+	return solidCard(816, 1116, color.Black)
+}
+
+func TestPackerCapacity(t *testing.T) {
+	p := NewPacker(Letter, 300, 0, DefaultCropMarkStyle(300))
+	// 8.5x11in @ 300dpi = 2550x3300px; first card sizes the grid.
+	p.Add(cardPx())
+	if got := p.Capacity(); got != 3*2 {
+		t.Fatalf("Capacity() = %d, want 6 (3 cols x 2 rows)", got)
+	}
+}
+
+func TestPackerSizesGridFromFirstCard(t *testing.T) {
+	p := NewPacker(Letter, 300, 0, DefaultCropMarkStyle(300))
+	// A much bigger bled card (e.g. a high-res auto-detected scan) must
+	// shrink the grid, not get silently cropped to a nominal slot size.
+	p.Add(solidCard(1644, 2244, color.Black))
+	if got := p.Capacity(); got != 1 {
+		t.Fatalf("Capacity() = %d, want 1 for an oversized card", got)
+	}
+}
+
+func TestPackerFlushesFullPage(t *testing.T) {
+	p := NewPacker(Letter, 300, 0, DefaultCropMarkStyle(300))
+	var page *Page
+	for page == nil {
+		page = p.Add(cardPx())
+	}
+	if page.Index != 1 {
+		t.Fatalf("page.Index = %d, want 1", page.Index)
+	}
+}
+
+func TestPackerAddReturnsNilUntilFull(t *testing.T) {
+	p := NewPacker(Letter, 300, 0, DefaultCropMarkStyle(300))
+	if page := p.Add(cardPx()); page != nil {
+		t.Fatal("expected nil before the page is full")
+	}
+}
+
+func TestPackerFlushCentersLeftovers(t *testing.T) {
+	p := NewPacker(Letter, 300, 0, DefaultCropMarkStyle(300))
+	p.Add(cardPx())
+	page := p.Flush()
+	if page == nil {
+		t.Fatal("Flush() returned nil with a pending card")
+	}
+
+	// A single leftover card should be centered, not pinned to the corner.
+	if got := page.Img.At(0, 0); !colorEq(got, color.White) {
+		t.Fatalf("top-left of page = %v, want white margin", got)
+	}
+}
+
+func colorEq(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}